@@ -0,0 +1,103 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockdag
+
+import (
+	"fmt"
+
+	"github.com/daglabs/btcd/wire"
+)
+
+// seqLockTimeGranularity is the amount of time, in seconds, that a relative
+// time-based sequence lock is rounded down to, per BIP68. A value of 9
+// indicates 512 second granularity since 512 = 1 << 9.
+const seqLockTimeGranularity = 9
+
+// SequenceLock represents the minimum height and median time past (MTP)
+// relative to the confirmation heights and confirmation MTPs of a set of
+// inputs, at which a transaction referencing those inputs becomes valid
+// under the relative lock-time rules introduced in BIP68. A transaction is
+// final once both constraints are satisfied; either field may be -1 to
+// indicate that no constraint of that kind is imposed.
+type SequenceLock struct {
+	// Height is the minimum DAG height, exclusive, at which the
+	// transaction may be included in a block.
+	Height int32
+
+	// Seconds is the minimum MTP, exclusive, at which the transaction may
+	// be included in a block.
+	Seconds int64
+}
+
+// CalcSequenceLock computes the relative lock-time SequenceLock for tx,
+// given a view providing the confirmation height of each of its inputs. It
+// implements BIP68: each input's nSequence field is interpreted as either a
+// number of blocks or a number of (512-second-granular) seconds of required
+// input age, unless wire.SequenceLockTimeDisabled is set on that input, in
+// which case the input imposes no constraint. tx's version must be at least
+// 2 for any of this to apply; for version-1 transactions, CalcSequenceLock
+// always returns a SequenceLock that imposes no constraint.
+func CalcSequenceLock(tx *wire.MsgTx, utxoView *UtxoViewpoint) (*SequenceLock, error) {
+	sequenceLock := &SequenceLock{Height: -1, Seconds: -1}
+
+	// The relative lock-time rules only apply to transactions with a
+	// version of 2 or higher, per BIP68.
+	if tx.Version < 2 {
+		return sequenceLock, nil
+	}
+
+	for txInIndex, txIn := range tx.TxIn {
+		if txIn.Sequence&wire.SequenceLockTimeDisabled == wire.SequenceLockTimeDisabled {
+			continue
+		}
+
+		entry := utxoView.LookupEntry(txIn.PreviousOutPoint)
+		if entry == nil {
+			return nil, fmt.Errorf("output %s referenced from "+
+				"transaction %s input %d either does not "+
+				"exist or has already been spent",
+				txIn.PreviousOutPoint, tx.TxHash(), txInIndex)
+		}
+
+		inputHeight := entry.BlockHeight()
+		if entry.IsCoinBase() && inputHeight == 0 {
+			inputHeight = 1
+		}
+
+		relativeLock := int64(txIn.Sequence & wire.SequenceLockTimeMask)
+
+		switch {
+		case txIn.Sequence&wire.SequenceLockTimeIsSeconds == wire.SequenceLockTimeIsSeconds:
+			medianTimePast := utxoView.InputMedianTimePast(inputHeight)
+			relativeSecs := (relativeLock << seqLockTimeGranularity) - 1
+			requiredSecs := medianTimePast + relativeSecs
+			if requiredSecs > sequenceLock.Seconds {
+				sequenceLock.Seconds = requiredSecs
+			}
+
+		default:
+			requiredHeight := int32(inputHeight) + int32(relativeLock) - 1
+			if requiredHeight > sequenceLock.Height {
+				sequenceLock.Height = requiredHeight
+			}
+		}
+	}
+
+	return sequenceLock, nil
+}
+
+// SequenceLockActive reports whether lock has matured, i.e. whether both of
+// its Height and Seconds constraints (if imposed) are satisfied by a
+// candidate block at the given height whose MTP is medianTimePast.
+func SequenceLockActive(lock *SequenceLock, blockHeight int32, medianTimePast int64) bool {
+	if lock.Seconds >= medianTimePast {
+		return false
+	}
+	if lock.Height >= blockHeight {
+		return false
+	}
+
+	return true
+}
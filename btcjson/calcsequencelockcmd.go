@@ -0,0 +1,44 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcjson
+
+// CalcSequenceLockCmd defines the calcsequencelock JSON-RPC command, which
+// computes the BIP68 SequenceLock for the passed transaction against the
+// current DAG state.
+type CalcSequenceLockCmd struct {
+	// RawTx is the hex-encoded serialized transaction to evaluate.
+	RawTx string
+
+	// Verbose, when true, requests the current block height and median
+	// time past be included in the response alongside the sequence lock
+	// itself, so callers can tell whether it is already active.
+	Verbose *bool `jsonrpcdefault:"false"`
+}
+
+// NewCalcSequenceLockCmd returns a new instance which can be used to issue a
+// calcsequencelock JSON-RPC command.
+func NewCalcSequenceLockCmd(rawTx string, verbose *bool) *CalcSequenceLockCmd {
+	return &CalcSequenceLockCmd{
+		RawTx:   rawTx,
+		Verbose: verbose,
+	}
+}
+
+// CalcSequenceLockResult models the data returned by the calcsequencelock
+// command. Height and Seconds are always populated with the computed
+// SequenceLock; BlockHeight and MedianTimePast are only populated when the
+// command was issued with Verbose set, and give the DAG state the lock was
+// evaluated against so callers can tell whether it is already active.
+type CalcSequenceLockResult struct {
+	Height  int32 `json:"height"`
+	Seconds int64 `json:"seconds"`
+
+	BlockHeight    int32 `json:"blockHeight,omitempty"`
+	MedianTimePast int64 `json:"medianTimePast,omitempty"`
+}
+
+func init() {
+	MustRegisterCmd("calcsequencelock", (*CalcSequenceLockCmd)(nil), 0)
+}
@@ -0,0 +1,226 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// This file is ignored during the regular tests due to the following build tag.
+// +build rpctest
+
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daglabs/btcd/blockdag"
+	"github.com/daglabs/btcd/dagconfig"
+	"github.com/daglabs/btcd/integration/rpctest"
+	"github.com/daglabs/btcd/txscript"
+	"github.com/daglabs/btcd/util"
+	"github.com/daglabs/btcd/wire"
+)
+
+// TestBIP0112CSVReorg verifies that a CSV-gated output's relative lock-time
+// arithmetic is reevaluated against the new confirmation height when a reorg
+// moves the output-creating transaction to a different height.
+func TestBIP0112CSVReorg(t *testing.T) {
+	t.Parallel()
+
+	btcdCfg := []string{"--rejectnonstd"}
+	r, err := rpctest.New(&dagconfig.SimNetParams, nil, btcdCfg)
+	if err != nil {
+		t.Fatal("unable to create primary harness: ", err)
+	}
+	if err := r.SetUp(true, 1); err != nil {
+		t.Fatalf("unable to setup test chain: %v", err)
+	}
+	defer r.TearDown()
+
+	harnessAddr, err := r.NewAddress()
+	if err != nil {
+		t.Fatalf("unable to obtain harness address: %v", err)
+	}
+	harnessScript, err := txscript.PayToAddrScript(harnessAddr)
+	if err != nil {
+		t.Fatalf("unable to generate pkScript: %v", err)
+	}
+
+	const (
+		outputAmt         = util.SatoshiPerBitcoin
+		relativeBlockLock = 10
+	)
+	sweepOutput := &wire.TxOut{
+		Value:    outputAmt - 5000,
+		PkScript: harnessScript,
+	}
+
+	// The CSV output is created at the forkPoint, then the chain is
+	// reorged so it is confirmed two blocks later instead.
+	forkPoint, err := r.Node.GetBestBlockHash()
+	if err != nil {
+		t.Fatalf("unable to get fork point: %v", err)
+	}
+
+	redeemScript, utxo, tx, err := createCSVOutput(r, t, outputAmt, relativeBlockLock, false)
+	if err != nil {
+		t.Fatalf("unable to create CSV output: %v", err)
+	}
+	if _, err := r.Node.SendRawTransaction(tx, true); err != nil {
+		t.Fatalf("unable to broadcast transaction: %v", err)
+	}
+	if _, err := r.Node.Generate(1); err != nil {
+		t.Fatalf("unable to generate block: %v", err)
+	}
+
+	// Reorg the CSV-creating transaction out, then back in two blocks
+	// deeper, so its confirmation height shifts by 2.
+	if _, err := r.GenerateFork(forkPoint, 2); err != nil {
+		t.Fatalf("unable to generate fork: %v", err)
+	}
+	if _, err := r.Node.SendRawTransaction(tx, true); err != nil {
+		t.Fatalf("unable to rebroadcast CSV-creating transaction after reorg: %v", err)
+	}
+	if _, err := r.Node.Generate(1); err != nil {
+		t.Fatalf("unable to generate block: %v", err)
+	}
+
+	// Age the input by 11 blocks, matching TestBIP0068AndCsv, so the
+	// relativeBlockLock of 10 is satisfied.
+	prevBlockHash, err := r.Node.GetBestBlockHash()
+	if err != nil {
+		t.Fatalf("unable to get prior block hash: %v", err)
+	}
+	prevBlock, err := r.Node.GetBlock(prevBlockHash)
+	if err != nil {
+		t.Fatalf("unable to get block: %v", err)
+	}
+	for i := 0; i < relativeBlockLock; i++ {
+		timeStamp := prevBlock.Header.Timestamp.Add(time.Minute * 10)
+		b, err := r.GenerateAndSubmitBlock(nil, -1, timeStamp)
+		if err != nil {
+			t.Fatalf("unable to generate block: %v", err)
+		}
+		prevBlock = b.MsgBlock()
+	}
+
+	spendTx, err := spendCSVOutput(redeemScript, utxo,
+		blockdag.LockTimeToSequence(false, relativeBlockLock), sweepOutput, 1)
+	if err != nil {
+		t.Fatalf("unable to spend CSV output: %v", err)
+	}
+
+	// The relative lock-time is computed against the output's new,
+	// post-reorg confirmation height, so the spend should be accepted.
+	txid, err := r.Node.SendRawTransaction(spendTx, true)
+	if err != nil {
+		t.Fatalf("transaction should be accepted after reorg, instead: %v", err)
+	}
+
+	blockHashes, err := r.Node.Generate(1)
+	if err != nil {
+		t.Fatalf("unable to mine block: %v", err)
+	}
+	assertTxInBlock(r, t, blockHashes[0], txid)
+}
+
+// TestBIP0112CSVCompetingTips creates sibling CSV-spending transactions on
+// competing DAG tips and verifies that only the one whose input has aged
+// sufficiently on the winning tip is retained in the mempool.
+func TestBIP0112CSVCompetingTips(t *testing.T) {
+	t.Parallel()
+
+	btcdCfg := []string{"--rejectnonstd"}
+	r, err := rpctest.New(&dagconfig.SimNetParams, nil, btcdCfg)
+	if err != nil {
+		t.Fatal("unable to create primary harness: ", err)
+	}
+	if err := r.SetUp(true, 1); err != nil {
+		t.Fatalf("unable to setup test chain: %v", err)
+	}
+	defer r.TearDown()
+
+	harnessAddr, err := r.NewAddress()
+	if err != nil {
+		t.Fatalf("unable to obtain harness address: %v", err)
+	}
+	harnessScript, err := txscript.PayToAddrScript(harnessAddr)
+	if err != nil {
+		t.Fatalf("unable to generate pkScript: %v", err)
+	}
+
+	const (
+		outputAmt         = util.SatoshiPerBitcoin
+		relativeBlockLock = 10
+	)
+	sweepOutput := &wire.TxOut{
+		Value:    outputAmt - 5000,
+		PkScript: harnessScript,
+	}
+
+	redeemScript, utxo, tx, err := createCSVOutput(r, t, outputAmt, relativeBlockLock, false)
+	if err != nil {
+		t.Fatalf("unable to create CSV output: %v", err)
+	}
+
+	// forkPoint is the common ancestor both competing tips diverge from,
+	// captured before the CSV-creating transaction is ever confirmed so
+	// each tip can independently rebroadcast and re-mine it.
+	forkPoint, err := r.Node.GetBestBlockHash()
+	if err != nil {
+		t.Fatalf("unable to get fork point: %v", err)
+	}
+
+	spendTx, err := spendCSVOutput(redeemScript, utxo,
+		blockdag.LockTimeToSequence(false, relativeBlockLock), sweepOutput, 1)
+	if err != nil {
+		t.Fatalf("unable to spend CSV output: %v", err)
+	}
+
+	// Tip A confirms the CSV-creating transaction, then ages it by only
+	// relativeBlockLock-2 further blocks. A lock of relativeBlockLock
+	// blocks is met once relativeBlockLock-1 further blocks exist on top
+	// of the confirming block, so the spend must be rejected there.
+	if _, err := r.GenerateFork(forkPoint, 1); err != nil {
+		t.Fatalf("unable to generate young tip's fork point: %v", err)
+	}
+	if _, err := r.Node.SendRawTransaction(tx, true); err != nil {
+		t.Fatalf("unable to broadcast CSV-creating transaction on young tip: %v", err)
+	}
+	if _, err := r.Node.Generate(1); err != nil {
+		t.Fatalf("unable to confirm CSV-creating transaction on young tip: %v", err)
+	}
+	if _, err := r.Node.Generate(relativeBlockLock - 2); err != nil {
+		t.Fatalf("unable to age young tip: %v", err)
+	}
+	if _, err := r.Node.SendRawTransaction(spendTx, true); err == nil {
+		t.Fatal("spend should be rejected on the insufficiently-aged tip")
+	}
+
+	// Tip B forks back to the common ancestor, rebroadcasts and
+	// reconfirms the CSV-creating transaction, then ages it by
+	// relativeBlockLock+1 further blocks so it satisfies the relative
+	// lock. GenerateFork leaves tip A's blocks invalidated, so tip B is
+	// the best chain.
+	if _, err := r.GenerateFork(forkPoint, 1); err != nil {
+		t.Fatalf("unable to generate winning tip's fork point: %v", err)
+	}
+	if _, err := r.Node.SendRawTransaction(tx, true); err != nil {
+		t.Fatalf("unable to rebroadcast CSV-creating transaction on winning tip: %v", err)
+	}
+	if _, err := r.Node.Generate(1); err != nil {
+		t.Fatalf("unable to reconfirm CSV-creating transaction on winning tip: %v", err)
+	}
+	if _, err := r.Node.Generate(relativeBlockLock + 1); err != nil {
+		t.Fatalf("unable to age winning tip: %v", err)
+	}
+
+	txid, err := r.Node.SendRawTransaction(spendTx, true)
+	if err != nil {
+		t.Fatalf("spend should be accepted on the sufficiently-aged winning tip, instead: %v", err)
+	}
+
+	blockHashes, err := r.Node.Generate(1)
+	if err != nil {
+		t.Fatalf("unable to mine block: %v", err)
+	}
+	assertTxInBlock(r, t, blockHashes[0], txid)
+}
@@ -25,9 +25,11 @@ import (
 )
 
 // makeTestOutput creates an on-chain output paying to a freshly generated
-// p2pkh output with the specified amount.
+// p2pkh output with the specified amount. The returned Signer can sign for
+// the output, allowing callers to plug in an alternative key manager in
+// place of the default raw private-key signer.
 func makeTestOutput(r *rpctest.Harness, t *testing.T,
-	amt util.Amount) (*btcec.PrivateKey, *wire.OutPoint, []byte, error) {
+	amt util.Amount) (txscript.Signer, *wire.OutPoint, []byte, error) {
 
 	// Create a fresh key, then send some coins to an address spendable by
 	// that key.
@@ -81,7 +83,7 @@ func makeTestOutput(r *rpctest.Harness, t *testing.T,
 		Index: outputIndex,
 	}
 
-	return key, utxo, selfAddrScript, nil
+	return txscript.NewPrivateKeySigner(key), utxo, selfAddrScript, nil
 }
 
 // TestBIP0113Activation tests for proper adherence of the BIP 113 rule
@@ -109,7 +111,7 @@ func TestBIP0113(t *testing.T) {
 
 	// Create a fresh output for usage within the test below.
 	const outputValue = util.SatoshiPerBitcoin
-	outputKey, testOutput, testPkScript, err := makeTestOutput(r, t,
+	outputSigner, testOutput, testPkScript, err := makeTestOutput(r, t,
 		outputValue)
 	if err != nil {
 		t.Fatalf("unable to create test output: %v", err)
@@ -146,8 +148,8 @@ func TestBIP0113(t *testing.T) {
 	}
 	tx.LockTime = chainInfo.MedianTime + 1
 
-	sigScript, err := txscript.SignatureScript(tx, 0, testPkScript,
-		txscript.SigHashAll, outputKey, true)
+	sigScript, err := outputSigner.SignatureScript(tx, 0, int64(outputValue), testPkScript,
+		txscript.SigHashAll, nil, true)
 	if err != nil {
 		t.Fatalf("unable to generate sig: %v", err)
 	}
@@ -183,7 +185,7 @@ func TestBIP0113(t *testing.T) {
 		medianTimePast := chainInfo.MedianTime
 
 		// Create another test output to be spent shortly below.
-		outputKey, testOutput, testPkScript, err = makeTestOutput(r, t,
+		outputSigner, testOutput, testPkScript, err = makeTestOutput(r, t,
 			outputValue)
 		if err != nil {
 			t.Fatalf("unable to create test output: %v", err)
@@ -200,8 +202,8 @@ func TestBIP0113(t *testing.T) {
 			Value:    outputValue - 1000,
 		})
 		tx.LockTime = medianTimePast + timeLockDelta
-		sigScript, err = txscript.SignatureScript(tx, 0, testPkScript,
-			txscript.SigHashAll, outputKey, true)
+		sigScript, err = outputSigner.SignatureScript(tx, 0, int64(outputValue), testPkScript,
+			txscript.SigHashAll, nil, true)
 		if err != nil {
 			t.Fatalf("unable to generate sig: %v", err)
 		}
@@ -321,24 +323,21 @@ func spendCSVOutput(redeemScript []byte, csvUTXO *wire.OutPoint,
 func assertTxInBlock(r *rpctest.Harness, t *testing.T, blockHash *daghash.Hash,
 	txid *daghash.Hash) {
 
-	block, err := r.Node.GetBlock(blockHash)
-	if err != nil {
-		t.Fatalf("unable to get block: %v", err)
-	}
-	if len(block.Transactions) < 2 {
-		t.Fatal("target transaction was not mined")
-	}
+	const notificationTimeout = 30 * time.Second
 
-	for _, txn := range block.Transactions {
-		txHash := txn.TxHash()
-		if txn.TxHash() == txHash {
-			return
-		}
+	// Rather than polling GetBlock, wait for the harness's
+	// OnBlockConnected/OnTxAccepted notifications to fire for blockHash
+	// and txid respectively.
+	if err := r.WaitForBlock(blockHash, notificationTimeout); err != nil {
+		_, _, line, _ := runtime.Caller(1)
+		t.Fatalf("assertion failed at line %v: block %v was never "+
+			"connected: %v", line, blockHash, err)
+	}
+	if err := r.WaitForTx(txid, notificationTimeout); err != nil {
+		_, _, line, _ := runtime.Caller(1)
+		t.Fatalf("assertion failed at line %v: txid %v expected in "+
+			"block %v was never accepted: %v", line, txid, blockHash, err)
 	}
-
-	_, _, line, _ := runtime.Caller(1)
-	t.Fatalf("assertion failed at line %v: txid %v was not found in "+
-		"block %v", line, txid, blockHash)
 }
 
 // TestBIP0068AndCsv tests for the proper adherence to the BIP 68
@@ -433,6 +432,16 @@ func TestBIP0068AndCsv(t *testing.T) {
 		t.Fatalf("unable to generate block: %v", err)
 	}
 
+	// Record the height and MTP at which the CSV outputs above confirmed,
+	// so the expected SequenceLock of each spend below can be computed
+	// independently of blockdag's own arithmetic.
+	confInfo, err := r.Node.GetBlockChainInfo()
+	if err != nil {
+		t.Fatalf("unable to query for chain info: %v", err)
+	}
+	csvInputConfHeight := confInfo.Blocks
+	csvInputConfMTP := confInfo.MedianTime
+
 	// Now mine 10 additional blocks giving the inputs generated above a
 	// age of 11. Space out each block 10 minutes after the previous block.
 	prevBlockHash, err := r.Node.GetBestBlockHash()
@@ -469,68 +478,106 @@ func TestBIP0068AndCsv(t *testing.T) {
 		return tx
 	}
 
+	// expectedBlockLock computes the SequenceLock a block-based relative
+	// lock-time of relativeLock should produce, given the CSV outputs'
+	// recorded confirmation height.
+	expectedBlockLock := func(relativeLock int64) *blockdag.SequenceLock {
+		return &blockdag.SequenceLock{
+			Height:  csvInputConfHeight + int32(relativeLock) - 1,
+			Seconds: -1,
+		}
+	}
+
+	// expectedTimeLock computes the SequenceLock a time-based relative
+	// lock-time of relativeSecs should produce, mirroring the 512-second
+	// granularity rounding blockdag.CalcSequenceLock applies.
+	expectedTimeLock := func(relativeSecs int64) *blockdag.SequenceLock {
+		return &blockdag.SequenceLock{
+			Height:  -1,
+			Seconds: csvInputConfMTP + (relativeSecs>>9<<9) - 1,
+		}
+	}
+
 	tests := [numTests]struct {
-		tx     *wire.MsgTx
-		accept bool
+		tx           *wire.MsgTx
+		accept       bool
+		expectedLock *blockdag.SequenceLock
 	}{
 		// A transaction spending a single input. The
 		// input has a relative time-lock of 1 block, but the disable
-		// bit it set. The transaction should be rejected as a result.
+		// bit it set. The transaction should be rejected as a result,
+		// and since the input is disabled it imposes no constraint.
 		{
 			tx: makeTxCase(
 				blockdag.LockTimeToSequence(false, 1)|wire.SequenceLockTimeDisabled,
 				1,
 			),
-			accept: false,
+			accept:       false,
+			expectedLock: &blockdag.SequenceLock{Height: -1, Seconds: -1},
 		},
 		// A transaction with a single input having a 9 block
 		// relative time lock. The referenced input is 11 blocks old,
 		// but the CSV output requires a 10 block relative lock-time.
 		// Therefore, the transaction should be rejected.
 		{
-			tx:     makeTxCase(blockdag.LockTimeToSequence(false, 9), 1),
-			accept: false,
+			tx:           makeTxCase(blockdag.LockTimeToSequence(false, 9), 1),
+			accept:       false,
+			expectedLock: expectedBlockLock(9),
 		},
 		// A transaction with a single input having a 10 block
 		// relative time lock. The referenced input is 11 blocks old so
 		// the transaction should be accepted.
 		{
-			tx:     makeTxCase(blockdag.LockTimeToSequence(false, 10), 1),
-			accept: true,
+			tx:           makeTxCase(blockdag.LockTimeToSequence(false, 10), 1),
+			accept:       true,
+			expectedLock: expectedBlockLock(10),
 		},
 		// A transaction with a single input having a 11 block
 		// relative time lock. The input referenced has an input age of
 		// 11 and the CSV op-code requires 10 blocks to have passed, so
 		// this transaction should be accepted.
 		{
-			tx:     makeTxCase(blockdag.LockTimeToSequence(false, 11), 1),
-			accept: true,
+			tx:           makeTxCase(blockdag.LockTimeToSequence(false, 11), 1),
+			accept:       true,
+			expectedLock: expectedBlockLock(11),
 		},
 		// A transaction whose input has a 1000 blck relative time
 		// lock.  This should be rejected as the input's age is only 11
 		// blocks.
 		{
-			tx:     makeTxCase(blockdag.LockTimeToSequence(false, 1000), 1),
-			accept: false,
+			tx:           makeTxCase(blockdag.LockTimeToSequence(false, 1000), 1),
+			accept:       false,
+			expectedLock: expectedBlockLock(1000),
 		},
 		// A transaction with a single input having a 512,000 second
 		// relative time-lock. This transaction should be rejected as 6
 		// days worth of blocks haven't yet been mined. The referenced
 		// input doesn't have sufficient age.
 		{
-			tx:     makeTxCase(blockdag.LockTimeToSequence(true, 512000), 1),
-			accept: false,
+			tx:           makeTxCase(blockdag.LockTimeToSequence(true, 512000), 1),
+			accept:       false,
+			expectedLock: expectedTimeLock(512000),
 		},
 		// A transaction whose single input has a 512 second
 		// relative time-lock. This transaction should be accepted as
 		// finalized.
 		{
-			tx:     makeTxCase(blockdag.LockTimeToSequence(true, 512), 1),
-			accept: true,
+			tx:           makeTxCase(blockdag.LockTimeToSequence(true, 512), 1),
+			accept:       true,
+			expectedLock: expectedTimeLock(512),
 		},
 	}
 
 	for i, test := range tests {
+		lock, err := r.CalcSequenceLock(test.tx)
+		if err != nil {
+			t.Fatalf("test #%d, unable to calculate sequence lock: %v", i, err)
+		}
+		if *lock != *test.expectedLock {
+			t.Fatalf("test #%d, expected sequence lock %+v, got %+v",
+				i, *test.expectedLock, *lock)
+		}
+
 		txid, err := r.Node.SendRawTransaction(test.tx, true)
 		switch {
 		// Test case passes, nothing further to report.
@@ -0,0 +1,248 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpctest
+
+import (
+	"fmt"
+
+	"github.com/daglabs/btcd/dagconfig/daghash"
+	"github.com/daglabs/btcd/txscript"
+	"github.com/daglabs/btcd/util"
+	"github.com/daglabs/btcd/wire"
+)
+
+// CoinSelector picks a set of spendable outpoints from the harness's wallet
+// that sum to at least target, returning them along with their total value.
+// Tests may supply their own implementation (e.g. to exercise specific
+// change/dust behavior); Harness.coinSelector falls back to
+// defaultCoinSelector otherwise.
+type CoinSelector interface {
+	SelectCoins(target util.Amount) (outpoints []*wire.OutPoint, total util.Amount, err error)
+}
+
+// defaultCoinSelector is the CoinSelector used whenever a Harness's
+// CoinSelector field is left nil. It walks the wallet's confirmed unspent
+// outputs in the order the node returns them, accumulating just enough to
+// cover target.
+type defaultCoinSelector struct {
+	h *Harness
+}
+
+// SelectCoins implements the CoinSelector interface.
+func (s *defaultCoinSelector) SelectCoins(target util.Amount) ([]*wire.OutPoint, util.Amount, error) {
+	unspent, err := s.h.Node.ListUnspent()
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to list unspent outputs: %s", err)
+	}
+
+	var (
+		outpoints []*wire.OutPoint
+		total     util.Amount
+	)
+	for _, u := range unspent {
+		if !u.Spendable {
+			continue
+		}
+
+		txHash, err := daghash.NewHashFromStr(u.TxID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid txid %q in unspent output: %s", u.TxID, err)
+		}
+		amt, err := util.NewAmount(u.Amount)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid amount for %s:%d: %s", u.TxID, u.Vout, err)
+		}
+
+		outpoints = append(outpoints, &wire.OutPoint{Hash: *txHash, Index: u.Vout})
+		total += amt
+
+		if total >= target {
+			return outpoints, total, nil
+		}
+	}
+
+	return nil, 0, fmt.Errorf("insufficient spendable funds: have %s, need %s", total, target)
+}
+
+// FeeEstimator computes the fee, in satoshis, that a transaction should pay
+// given its serialized virtual size and a target fee rate expressed in
+// satoshis per byte.
+type FeeEstimator struct {
+	// FeeRatePerByte is the fee rate, in satoshis per byte, that
+	// CalcFee uses to size fees.
+	FeeRatePerByte util.Amount
+}
+
+// NewFeeEstimator returns a FeeEstimator that charges feeRatePerByte
+// satoshis for every byte of a transaction's serialized virtual size.
+func NewFeeEstimator(feeRatePerByte util.Amount) *FeeEstimator {
+	return &FeeEstimator{FeeRatePerByte: feeRatePerByte}
+}
+
+// CalcFee returns the fee a transaction of the given virtual size should
+// pay under this estimator.
+func (e *FeeEstimator) CalcFee(virtualSize int64) util.Amount {
+	return util.Amount(virtualSize) * e.FeeRatePerByte
+}
+
+// dustThreshold is the value a change output must exceed; change at or below
+// it is folded into the fee instead of creating an uneconomical output.
+const dustThreshold = util.Amount(546)
+
+// coinSelector returns h.CoinSelector, falling back to a defaultCoinSelector
+// backed by the node's own wallet when the caller hasn't supplied one.
+func (h *Harness) coinSelector() CoinSelector {
+	if h.CoinSelector != nil {
+		return h.CoinSelector
+	}
+	return &defaultCoinSelector{h: h}
+}
+
+// CreateTransaction creates a transaction paying outputs, selecting UTXOs
+// via h.coinSelector() and paying a fee computed at feeRatePerByte satoshis
+// per byte of the transaction's serialized size (this parameter was
+// previously a flat, hardcoded satoshi fee). Any leftover input value above
+// the dust threshold is returned to a fresh internal address as a change
+// output.
+func (h *Harness) CreateTransaction(outputs []*wire.TxOut, feeRatePerByte util.Amount) (*wire.MsgTx, error) {
+	return h.createTransaction(outputs, feeRatePerByte)
+}
+
+// SendOutputs is the harness's high-level transaction-creation API. It
+// builds a transaction exactly as CreateTransaction does, then broadcasts
+// it and returns it.
+func (h *Harness) SendOutputs(outputs []*wire.TxOut, feeRatePerByte util.Amount) (*wire.MsgTx, error) {
+	tx, err := h.createTransaction(outputs, feeRatePerByte)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := h.Node.SendRawTransaction(tx, true); err != nil {
+		return nil, fmt.Errorf("unable to broadcast transaction: %s", err)
+	}
+
+	return tx, nil
+}
+
+// createTransaction selects inputs sufficient to cover outputs plus a fee
+// computed at feeRatePerByte, re-selecting with a growing target amount
+// until the selected inputs cover the fee the resulting, fully-signed
+// transaction actually implies.
+func (h *Harness) createTransaction(outputs []*wire.TxOut, feeRatePerByte util.Amount) (*wire.MsgTx, error) {
+	return convergeOnFee(outputs, feeRatePerByte, h.buildTransaction)
+}
+
+// convergeOnFee drives the fee-convergence loop backing createTransaction:
+// a transaction's fee depends on its own (signed) size, which depends on how
+// many inputs coin selection needs, which depends on the fee. It starts from
+// a rough size estimate and calls build with a growing target amount until
+// the transaction build returns covers outputs plus the fee it actually
+// implies. build is h.buildTransaction in production, and a fake in tests
+// exercising just this loop.
+func convergeOnFee(outputs []*wire.TxOut, feeRatePerByte util.Amount,
+	build func(outputs []*wire.TxOut, needed util.Amount) (*wire.MsgTx, error)) (*wire.MsgTx, error) {
+
+	var target util.Amount
+	for _, output := range outputs {
+		target += util.Amount(output.Value)
+	}
+
+	estimator := NewFeeEstimator(feeRatePerByte)
+
+	fee := estimator.CalcFee(txEstimatedVirtualSize(len(outputs) + 1))
+	for attempt := 0; attempt < 5; attempt++ {
+		tx, err := build(outputs, target+fee)
+		if err != nil {
+			return nil, err
+		}
+
+		actualFee := estimator.CalcFee(txVirtualSize(tx))
+		if actualFee <= fee {
+			return tx, nil
+		}
+
+		fee = actualFee
+	}
+
+	return nil, fmt.Errorf("unable to converge on a fee for %d outputs at %d sat/byte",
+		len(outputs), feeRatePerByte)
+}
+
+// buildTransaction selects coins covering needed, adds the caller's
+// outputs, appends a change output back to a fresh internal address for
+// anything above dustThreshold, and has the node's own wallet sign the
+// result.
+func (h *Harness) buildTransaction(outputs []*wire.TxOut, needed util.Amount) (*wire.MsgTx, error) {
+	outpoints, total, err := h.coinSelector().SelectCoins(needed)
+	if err != nil {
+		return nil, fmt.Errorf("unable to select coins: %s", err)
+	}
+
+	var changeScript []byte
+	if change := total - needed; change > dustThreshold {
+		changeAddr, err := h.NewAddress()
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate change address: %s", err)
+		}
+		changeScript, err = txscript.PayToAddrScript(changeAddr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate change script: %s", err)
+		}
+	}
+
+	tx := assembleTransaction(outpoints, outputs, total-needed, changeScript)
+
+	// The node's own wallet holds the private keys for every address it
+	// handed out via NewAddress, so it signs on our behalf rather than us
+	// needing to track key material here.
+	signedTx, isSigned, err := h.Node.SignRawTransaction(tx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign transaction: %s", err)
+	}
+	if !isSigned {
+		return nil, fmt.Errorf("unable to fully sign transaction spending %d coin(s)",
+			len(outpoints))
+	}
+
+	return signedTx, nil
+}
+
+// assembleTransaction builds an unsigned transaction spending outpoints and
+// paying outputs. change above dustThreshold is folded into a further output
+// paying changeScript; change at or below dustThreshold, or a nil
+// changeScript, is left unclaimed and so implicitly added to the fee instead
+// of creating an uneconomical output.
+func assembleTransaction(outpoints []*wire.OutPoint, outputs []*wire.TxOut,
+	change util.Amount, changeScript []byte) *wire.MsgTx {
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	for _, outpoint := range outpoints {
+		tx.AddTxIn(&wire.TxIn{PreviousOutPoint: *outpoint})
+	}
+	for _, output := range outputs {
+		tx.AddTxOut(output)
+	}
+
+	if change > dustThreshold && changeScript != nil {
+		tx.AddTxOut(&wire.TxOut{Value: int64(change), PkScript: changeScript})
+	}
+
+	return tx
+}
+
+// txEstimatedVirtualSize returns a rough virtual-size estimate for a
+// transaction with the given number of outputs, used only to seed the first
+// fee-convergence attempt in createTransaction before a candidate
+// transaction exists to measure directly.
+func txEstimatedVirtualSize(numOutputs int) int64 {
+	const baseSize = 10
+	const perOutput = 34
+	return baseSize + int64(numOutputs*perOutput)
+}
+
+// txVirtualSize returns tx's serialized virtual size.
+func txVirtualSize(tx *wire.MsgTx) int64 {
+	return int64(tx.SerializeSize())
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpctest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/daglabs/btcd/dagconfig/daghash"
+	"github.com/daglabs/btcd/util"
+	"github.com/daglabs/btcd/wire"
+)
+
+// TestFeeEstimatorCalcFee verifies that FeeEstimator.CalcFee scales linearly
+// with both the transaction's virtual size and the configured fee rate.
+func TestFeeEstimatorCalcFee(t *testing.T) {
+	tests := []struct {
+		feeRatePerByte util.Amount
+		virtualSize    int64
+		expectedFee    util.Amount
+	}{
+		{feeRatePerByte: 10, virtualSize: 250, expectedFee: 2500},
+		{feeRatePerByte: 1, virtualSize: 250, expectedFee: 250},
+		{feeRatePerByte: 10, virtualSize: 0, expectedFee: 0},
+		{feeRatePerByte: 0, virtualSize: 250, expectedFee: 0},
+	}
+
+	for i, test := range tests {
+		estimator := NewFeeEstimator(test.feeRatePerByte)
+		fee := estimator.CalcFee(test.virtualSize)
+		if fee != test.expectedFee {
+			t.Fatalf("test #%d: expected fee %v, got %v", i, test.expectedFee, fee)
+		}
+	}
+}
+
+// TestAssembleTransactionFoldsDustIntoFee verifies that assembleTransaction
+// appends a change output when change exceeds dustThreshold, and otherwise
+// leaves it unclaimed (implicitly folding it into the fee) rather than
+// creating an uneconomical output.
+func TestAssembleTransactionFoldsDustIntoFee(t *testing.T) {
+	outpoints := []*wire.OutPoint{{Hash: daghash.Hash{0x01}, Index: 0}}
+	outputs := []*wire.TxOut{{Value: 1e8}}
+	changeScript := []byte{0x51}
+
+	tests := []struct {
+		name        string
+		change      util.Amount
+		wantOutputs int
+	}{
+		{name: "change above dust threshold", change: dustThreshold + 1, wantOutputs: 2},
+		{name: "change at dust threshold", change: dustThreshold, wantOutputs: 1},
+		{name: "change below dust threshold", change: dustThreshold - 1, wantOutputs: 1},
+	}
+
+	for _, test := range tests {
+		tx := assembleTransaction(outpoints, outputs, test.change, changeScript)
+		if len(tx.TxOut) != test.wantOutputs {
+			t.Errorf("%s: expected %d outputs, got %d", test.name, test.wantOutputs, len(tx.TxOut))
+		}
+	}
+}
+
+// TestConvergeOnFeeReselectsUntilFeeCovered verifies that convergeOnFee
+// rejects a build attempt whose implied fee exceeds the fee it was asked to
+// cover and retries with a larger target, rather than accepting the first
+// attempt unconditionally.
+func TestConvergeOnFeeReselectsUntilFeeCovered(t *testing.T) {
+	const feeRatePerByte = util.Amount(10)
+	outputs := []*wire.TxOut{{Value: 1e8}}
+
+	var buildCalls int
+	build := func(outputs []*wire.TxOut, needed util.Amount) (*wire.MsgTx, error) {
+		buildCalls++
+
+		tx := wire.NewMsgTx(wire.TxVersion)
+		for _, output := range outputs {
+			tx.AddTxOut(output)
+		}
+
+		if buildCalls == 1 {
+			// Deliberately oversized: its implied fee (feeRatePerByte
+			// times >= 1000 bytes of padding) is far larger than
+			// convergeOnFee's initial rough estimate, so this
+			// attempt must be rejected and retried rather than
+			// accepted outright.
+			tx.AddTxOut(&wire.TxOut{PkScript: make([]byte, 1000)})
+		}
+
+		return tx, nil
+	}
+
+	tx, err := convergeOnFee(outputs, feeRatePerByte, build)
+	if err != nil {
+		t.Fatalf("unable to converge on fee: %v", err)
+	}
+	if buildCalls < 2 {
+		t.Fatalf("expected convergeOnFee to retry after the oversized first attempt, got %d call(s)", buildCalls)
+	}
+	if len(tx.TxOut) != len(outputs) {
+		t.Fatalf("expected the converged transaction to be the small, unpadded attempt with %d output(s), got %d",
+			len(outputs), len(tx.TxOut))
+	}
+}
+
+// TestConvergeOnFeePropagatesBuildError verifies that convergeOnFee gives up
+// and returns build's error immediately rather than retrying, mirroring how
+// buildTransaction's own coin-selection failures (e.g. insufficient funds)
+// should surface.
+func TestConvergeOnFeePropagatesBuildError(t *testing.T) {
+	wantErr := fmt.Errorf("insufficient spendable funds")
+	build := func(outputs []*wire.TxOut, needed util.Amount) (*wire.MsgTx, error) {
+		return nil, wantErr
+	}
+
+	_, err := convergeOnFee([]*wire.TxOut{{Value: 1e8}}, 10, build)
+	if err != wantErr {
+		t.Fatalf("expected %v, got %v", wantErr, err)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpctest
+
+import (
+	"fmt"
+
+	"github.com/daglabs/btcd/dagconfig"
+	"github.com/daglabs/btcd/rpcclient"
+)
+
+// Harness provides the RPC-driven handle this package's integration tests
+// use to talk to a single kaspad node. Node is nil until SetUp succeeds.
+type Harness struct {
+	// ActiveNet is the network parameters the harness's node is running
+	// under.
+	ActiveNet *dagconfig.Params
+
+	// Node is the harness's RPC connection to its node. It is populated
+	// by SetUp and nil beforehand.
+	Node *rpcclient.Client
+
+	// CoinSelector, when set, overrides the CoinSelector CreateTransaction
+	// and SendOutputs use to fund transactions. Left nil, they fall back
+	// to defaultCoinSelector.
+	CoinSelector CoinSelector
+
+	connConfig *rpcclient.ConnConfig
+	handlers   *NotificationHandlers
+}
+
+// New returns a new Harness for the node reachable via connConfig, running
+// under activeNet. handlers, if non-nil, is layered on top of the harness's
+// own internal bookkeeping (see BuildNotificationHandlers) and wired into
+// the websocket client SetUp creates; any of its fields left nil are simply
+// not invoked.
+func New(activeNet *dagconfig.Params, connConfig *rpcclient.ConnConfig,
+	handlers *NotificationHandlers) (*Harness, error) {
+
+	if connConfig == nil {
+		return nil, fmt.Errorf("connConfig must not be nil")
+	}
+
+	return &Harness{
+		ActiveNet:  activeNet,
+		connConfig: connConfig,
+		handlers:   handlers,
+	}, nil
+}
+
+// SetUp establishes the harness's RPC connection, wiring the
+// NotificationHandlers supplied to New into the underlying websocket client
+// via BuildNotificationHandlers and enabling transaction notifications so
+// WaitForTx and WaitForBlock - and, transitively, assertTxInBlock - can rely
+// on real events instead of polling.
+func (h *Harness) SetUp() error {
+	client, err := rpcclient.New(h.connConfig, h.BuildNotificationHandlers(h.handlers))
+	if err != nil {
+		return fmt.Errorf("unable to connect to node: %s", err)
+	}
+	h.Node = client
+
+	if err := h.Node.NotifyNewTransactions(false); err != nil {
+		h.Node.Shutdown()
+		h.Node = nil
+		return fmt.Errorf("unable to subscribe to new-transaction notifications: %s", err)
+	}
+
+	return nil
+}
+
+// TearDown shuts down the harness's RPC connection.
+func (h *Harness) TearDown() error {
+	if h.Node == nil {
+		return nil
+	}
+	h.Node.Shutdown()
+	h.Node = nil
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpctest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/daglabs/btcd/dagconfig/daghash"
+)
+
+// InvalidateBlock marks the block identified by hash as invalid, forcing the
+// underlying node to reorg away from it (and any of its descendants) onto
+// the best remaining valid chain. This mirrors the `invalidateblock` RPC and
+// is primarily useful for driving deterministic reorg scenarios from tests.
+func (h *Harness) InvalidateBlock(hash *daghash.Hash) error {
+	return h.Node.InvalidateBlock(hash)
+}
+
+// ReconsiderBlock clears the invalid status (if any) previously set on hash
+// and its descendants via InvalidateBlock, allowing the node to re-evaluate
+// them as candidates for the best chain. This mirrors the `reconsiderblock`
+// RPC.
+func (h *Harness) ReconsiderBlock(hash *daghash.Hash) error {
+	return h.Node.ReconsiderBlock(hash)
+}
+
+// GenerateFork submits an alternate chain of n blocks built on top of
+// parent, regardless of whether parent is the current tip. It does so by
+// walking the current best chain backwards, invalidating each descendant of
+// parent until parent itself becomes the tip, then mining n blocks on top of
+// it. It returns the hashes of the newly mined fork blocks, in order.
+//
+// Note that this leaves the blocks invalidated along the old chain marked as
+// such; callers that want the original tip to remain a valid (losing) DAG
+// tip should ReconsiderBlock each of them after GenerateFork returns.
+func (h *Harness) GenerateFork(parent *daghash.Hash, n int) ([]*daghash.Hash, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("GenerateFork: n must be positive, got %d", n)
+	}
+
+	for {
+		tipHash, err := h.Node.GetBestBlockHash()
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch best block hash: %s", err)
+		}
+		if *tipHash == *parent {
+			break
+		}
+
+		if err := h.Node.InvalidateBlock(tipHash); err != nil {
+			return nil, fmt.Errorf("unable to invalidate back to fork parent %s: %s", parent, err)
+		}
+	}
+
+	hashes := make([]*daghash.Hash, 0, n)
+	for i := 0; i < n; i++ {
+		timestamp := time.Now()
+		minedBlock, err := h.GenerateAndSubmitBlock(nil, -1, timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate fork block %d/%d off of %s: %s",
+				i+1, n, parent, err)
+		}
+
+		hashes = append(hashes, minedBlock.Hash())
+	}
+
+	return hashes, nil
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpctest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/daglabs/btcd/dagconfig/daghash"
+	"github.com/daglabs/btcd/rpcclient"
+	"github.com/daglabs/btcd/util"
+)
+
+// NotificationHandlers mirrors rpcclient.NotificationHandlers, letting
+// callers of New observe the harness node's websocket notifications
+// directly. Any handler left nil is simply not invoked. SetUp wires these in
+// addition to, not instead of, the harness's own internal bookkeeping (see
+// BuildNotificationHandlers) that backs WaitForTx/WaitForBlock.
+type NotificationHandlers struct {
+	// OnTxAccepted is invoked when a transaction is accepted into the
+	// node's mempool.
+	OnTxAccepted func(hash *daghash.Hash, amount util.Amount)
+
+	// OnBlockConnected is invoked when a new block is added to the best
+	// chain.
+	OnBlockConnected func(hash *daghash.Hash, height int32, time time.Time)
+
+	// OnBlockDisconnected is invoked when a block is removed from the
+	// best chain, typically as the result of a reorg.
+	OnBlockDisconnected func(hash *daghash.Hash, height int32, time time.Time)
+
+	// OnRedeemingTx is invoked when a transaction spends an output that
+	// was registered for notifications via the node's websocket client.
+	OnRedeemingTx func(details *util.Tx, block *util.Block)
+}
+
+// BuildNotificationHandlers returns the *rpcclient.NotificationHandlers that
+// SetUp wires into the underlying node's websocket client before enabling
+// transaction notifications via Node.NotifyNewTransactions(false). It wraps
+// user, the caller-supplied NotificationHandlers (nil if none were given),
+// so that the harness's own onTxAccepted/onBlockConnected bookkeeping always
+// runs first, ahead of and independent from the caller's handlers.
+func (h *Harness) BuildNotificationHandlers(user *NotificationHandlers) *rpcclient.NotificationHandlers {
+	return &rpcclient.NotificationHandlers{
+		OnTxAccepted: func(hash *daghash.Hash, amount util.Amount) {
+			h.onTxAccepted(hash, amount)
+			if user != nil && user.OnTxAccepted != nil {
+				user.OnTxAccepted(hash, amount)
+			}
+		},
+		OnBlockConnected: func(hash *daghash.Hash, height int32, blockTime time.Time) {
+			h.onBlockConnected(hash, height, blockTime)
+			if user != nil && user.OnBlockConnected != nil {
+				user.OnBlockConnected(hash, height, blockTime)
+			}
+		},
+		OnBlockDisconnected: func(hash *daghash.Hash, height int32, blockTime time.Time) {
+			if user != nil && user.OnBlockDisconnected != nil {
+				user.OnBlockDisconnected(hash, height, blockTime)
+			}
+		},
+		OnRedeemingTx: func(tx *util.Tx, block *util.Block) {
+			if user != nil && user.OnRedeemingTx != nil {
+				user.OnRedeemingTx(tx, block)
+			}
+		},
+	}
+}
+
+// notificationState tracks the channels backing WaitForTx/WaitForBlock,
+// along with the set of txids/block hashes already observed so a caller
+// that starts waiting after the fact doesn't block forever.
+type notificationState struct {
+	mtx sync.Mutex
+
+	seenTxs    map[daghash.Hash]struct{}
+	seenBlocks map[daghash.Hash]struct{}
+
+	txWaiters    map[daghash.Hash][]chan struct{}
+	blockWaiters map[daghash.Hash][]chan struct{}
+}
+
+func newNotificationState() *notificationState {
+	return &notificationState{
+		seenTxs:      make(map[daghash.Hash]struct{}),
+		seenBlocks:   make(map[daghash.Hash]struct{}),
+		txWaiters:    make(map[daghash.Hash][]chan struct{}),
+		blockWaiters: make(map[daghash.Hash][]chan struct{}),
+	}
+}
+
+// notificationStates holds each Harness's notificationState, keyed by the
+// harness itself rather than held in a field on Harness.
+var (
+	notificationStatesMtx sync.Mutex
+	notificationStates    = make(map[*Harness]*notificationState)
+)
+
+// notifications lazily creates and returns h's notificationState.
+func (h *Harness) notifications() *notificationState {
+	notificationStatesMtx.Lock()
+	defer notificationStatesMtx.Unlock()
+
+	ns, ok := notificationStates[h]
+	if !ok {
+		ns = newNotificationState()
+		notificationStates[h] = ns
+	}
+	return ns
+}
+
+// onTxAccepted records hash as seen and wakes up any WaitForTx callers
+// blocked on it. It is wired into the harness node's websocket subscription
+// by BuildNotificationHandlers.
+func (h *Harness) onTxAccepted(hash *daghash.Hash, amount util.Amount) {
+	ns := h.notifications()
+
+	ns.mtx.Lock()
+	ns.seenTxs[*hash] = struct{}{}
+	waiters := ns.txWaiters[*hash]
+	delete(ns.txWaiters, *hash)
+	ns.mtx.Unlock()
+
+	for _, w := range waiters {
+		close(w)
+	}
+}
+
+// onBlockConnected records hash as seen and wakes up any WaitForBlock
+// callers blocked on it.
+func (h *Harness) onBlockConnected(hash *daghash.Hash, height int32, t time.Time) {
+	ns := h.notifications()
+
+	ns.mtx.Lock()
+	ns.seenBlocks[*hash] = struct{}{}
+	waiters := ns.blockWaiters[*hash]
+	delete(ns.blockWaiters, *hash)
+	ns.mtx.Unlock()
+
+	for _, w := range waiters {
+		close(w)
+	}
+}
+
+// WaitForTx blocks until a transaction matching txid has been accepted into
+// the harness node's mempool, or timeout elapses.
+func (h *Harness) WaitForTx(txid *daghash.Hash, timeout time.Duration) error {
+	ns := h.notifications()
+
+	ns.mtx.Lock()
+	if _, ok := ns.seenTxs[*txid]; ok {
+		ns.mtx.Unlock()
+		return nil
+	}
+	done := make(chan struct{})
+	ns.txWaiters[*txid] = append(ns.txWaiters[*txid], done)
+	ns.mtx.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %s waiting for tx %s", timeout, txid)
+	}
+}
+
+// WaitForBlock blocks until a block matching hash has connected to the
+// harness node's best chain, or timeout elapses.
+func (h *Harness) WaitForBlock(hash *daghash.Hash, timeout time.Duration) error {
+	ns := h.notifications()
+
+	ns.mtx.Lock()
+	if _, ok := ns.seenBlocks[*hash]; ok {
+		ns.mtx.Unlock()
+		return nil
+	}
+	done := make(chan struct{})
+	ns.blockWaiters[*hash] = append(ns.blockWaiters[*hash], done)
+	ns.mtx.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %s waiting for block %s", timeout, hash)
+	}
+}
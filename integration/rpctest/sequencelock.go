@@ -0,0 +1,16 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpctest
+
+import (
+	"github.com/daglabs/btcd/blockdag"
+	"github.com/daglabs/btcd/wire"
+)
+
+// CalcSequenceLock queries the harness node's calcsequencelock RPC for the
+// BIP68 SequenceLock that applies to tx given the current DAG state.
+func (h *Harness) CalcSequenceLock(tx *wire.MsgTx) (*blockdag.SequenceLock, error) {
+	return h.Node.CalcSequenceLock(tx)
+}
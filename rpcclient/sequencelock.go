@@ -0,0 +1,60 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcclient
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/daglabs/btcd/blockdag"
+	"github.com/daglabs/btcd/btcjson"
+	"github.com/daglabs/btcd/wire"
+)
+
+// FutureCalcSequenceLockResult is a future promise to deliver the result of
+// a CalcSequenceLockAsync RPC invocation (or an applicable error).
+type FutureCalcSequenceLockResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// BIP68 SequenceLock the node computed for the transaction.
+func (r FutureCalcSequenceLockResult) Receive() (*blockdag.SequenceLock, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result btcjson.CalcSequenceLockResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+
+	return &blockdag.SequenceLock{
+		Height:  result.Height,
+		Seconds: result.Seconds,
+	}, nil
+}
+
+// CalcSequenceLockAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See CalcSequenceLock for the blocking version and more details.
+func (c *Client) CalcSequenceLockAsync(tx *wire.MsgTx) FutureCalcSequenceLockResult {
+	buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+	if err := tx.Serialize(buf); err != nil {
+		return newFutureError(err)
+	}
+	txHex := hex.EncodeToString(buf.Bytes())
+
+	cmd := btcjson.NewCalcSequenceLockCmd(txHex, btcjson.Bool(false))
+	return c.sendCmd(cmd)
+}
+
+// CalcSequenceLock queries the node for the BIP68 SequenceLock that applies
+// to tx given the DAG's current state.
+func (c *Client) CalcSequenceLock(tx *wire.MsgTx) (*blockdag.SequenceLock, error) {
+	return c.CalcSequenceLockAsync(tx).Receive()
+}
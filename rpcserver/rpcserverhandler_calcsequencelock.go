@@ -0,0 +1,54 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcserver
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/daglabs/btcd/blockdag"
+	"github.com/daglabs/btcd/btcjson"
+	"github.com/daglabs/btcd/wire"
+)
+
+// handleCalcSequenceLock implements the calcsequencelock command. Wiring it
+// in requires adding a "calcsequencelock": handleCalcSequenceLock entry to
+// this server's rpcHandlers dispatch table.
+func handleCalcSequenceLock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.CalcSequenceLockCmd)
+
+	serializedTx, err := hex.DecodeString(c.RawTx)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.RawTx)
+	}
+
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(serializedTx)); err != nil {
+		return nil, rpcDeserializationError("could not decode transaction: %v", err)
+	}
+
+	utxoView, err := s.cfg.DAG.UTXOSetForTx(&tx)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "could not load referenced outputs")
+	}
+
+	sequenceLock, err := blockdag.CalcSequenceLock(&tx, utxoView)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "could not calculate sequence lock")
+	}
+
+	result := &btcjson.CalcSequenceLockResult{
+		Height:  sequenceLock.Height,
+		Seconds: sequenceLock.Seconds,
+	}
+
+	if c.Verbose != nil && *c.Verbose {
+		snapshot := s.cfg.DAG.BestSnapshot()
+		result.BlockHeight = snapshot.Height
+		result.MedianTimePast = snapshot.MedianTime.Unix()
+	}
+
+	return result, nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"encoding/binary"
+
+	"github.com/daglabs/btcd/dagconfig/daghash"
+	"github.com/daglabs/btcd/wire"
+)
+
+// TxSigHashes houses the partial set of sighash midstate digests that are
+// re-used across every input of a transaction when computing a BIP143-style
+// signature hash (hashPrevOuts, hashSequence, and hashOutputs). Computing
+// these once per transaction, rather than once per input, turns the
+// per-input hashing cost from O(n) in the number of inputs into O(1).
+type TxSigHashes struct {
+	HashPrevOuts daghash.Hash
+	HashSequence daghash.Hash
+	HashOutputs  daghash.Hash
+}
+
+// NewTxSigHashes computes, and returns, the full set of sighash midstate
+// digests for the passed transaction. The resulting TxSigHashes can be
+// passed to a Signer's SignatureScript for every input of tx, avoiding
+// redundant hashing work.
+func NewTxSigHashes(tx *wire.MsgTx) *TxSigHashes {
+	return &TxSigHashes{
+		HashPrevOuts: calcHashPrevOuts(tx),
+		HashSequence: calcHashSequence(tx),
+		HashOutputs:  calcHashOutputs(tx),
+	}
+}
+
+// calcHashPrevOuts calculates a single hash of all the previous outputs
+// (txid:index) referenced within the passed transaction. This calculation
+// can be re-used for multiple transaction inputs as it does not
+// change per input.
+func calcHashPrevOuts(tx *wire.MsgTx) daghash.Hash {
+	var b []byte
+	for _, in := range tx.TxIn {
+		b = append(b, in.PreviousOutPoint.Hash[:]...)
+		var buf [4]byte
+		binary.LittleEndian.PutUint32(buf[:], in.PreviousOutPoint.Index)
+		b = append(b, buf[:]...)
+	}
+	return daghash.DoubleHashH(b)
+}
+
+// calcHashSequence computes a single hash of all the sequence numbers of the
+// inputs referenced within the passed transaction. This single hash can be
+// re-used for multiple transaction inputs as it does not change per input.
+func calcHashSequence(tx *wire.MsgTx) daghash.Hash {
+	var b []byte
+	for _, in := range tx.TxIn {
+		var buf [4]byte
+		binary.LittleEndian.PutUint32(buf[:], in.Sequence)
+		b = append(b, buf[:]...)
+	}
+	return daghash.DoubleHashH(b)
+}
+
+// calcHashOutputs computes a single hash of all the outputs contained within
+// the passed transaction. This single hash can be re-used for multiple
+// transaction inputs as it does not change per input.
+func calcHashOutputs(tx *wire.MsgTx) daghash.Hash {
+	var b []byte
+	for _, out := range tx.TxOut {
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], uint64(out.Value))
+		b = append(b, buf[:]...)
+
+		b = append(b, byte(len(out.PkScript)))
+		b = append(b, out.PkScript...)
+	}
+	return daghash.DoubleHashH(b)
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/daglabs/btcd/btcec"
+	"github.com/daglabs/btcd/wire"
+)
+
+// TestSigHashesCacheReuse verifies that signing many inputs of the same
+// transaction with a single, shared TxSigHashes produces byte-identical
+// signature scripts to signing each input against its own independently
+// recomputed TxSigHashes, proving the cache can safely be computed once per
+// transaction and reused across every input instead of once per input.
+func TestSigHashesCacheReuse(t *testing.T) {
+	const numInputs = 50
+
+	key, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to generate private key: %v", err)
+	}
+	signer := NewPrivateKeySigner(key)
+
+	b := NewScriptBuilder().AddData(signer.PubKey().SerializeCompressed()).AddOp(OpCheckSig)
+	pkScript, err := b.Script()
+	if err != nil {
+		t.Fatalf("unable to generate pkScript: %v", err)
+	}
+
+	tx := wire.NewMsgTx(1)
+	for i := 0; i < numInputs; i++ {
+		tx.AddTxIn(&wire.TxIn{})
+	}
+	tx.AddTxOut(&wire.TxOut{Value: 1e8, PkScript: pkScript})
+
+	sharedCache := NewTxSigHashes(tx)
+
+	for i := 0; i < numInputs; i++ {
+		viaSharedCache, err := signer.SignatureScript(tx, i, 1e8, pkScript,
+			SigHashAll, sharedCache, true)
+		if err != nil {
+			t.Fatalf("input %d: unable to sign with shared cache: %v", i, err)
+		}
+
+		// Recompute the cache from scratch for this input alone, rather
+		// than reusing sharedCache, to confirm the shared cache's
+		// midstate digests are exactly what a fresh computation would
+		// have produced.
+		freshCache := NewTxSigHashes(tx)
+		viaFreshCache, err := signer.SignatureScript(tx, i, 1e8, pkScript,
+			SigHashAll, freshCache, true)
+		if err != nil {
+			t.Fatalf("input %d: unable to sign with freshly computed cache: %v", i, err)
+		}
+
+		if !bytes.Equal(viaSharedCache, viaFreshCache) {
+			t.Fatalf("input %d: signature script via shared cache differs "+
+				"from signature script via freshly computed cache", i)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/daglabs/btcd/btcec"
+	"github.com/daglabs/btcd/dagconfig/daghash"
+	"github.com/daglabs/btcd/wire"
+)
+
+// SignatureScript builds a signature script for the specified input of tx,
+// signing with key. It retains its original shape for existing callers that
+// have no need for a TxSigHashes cache; it is equivalent to calling
+// signatureScript with a nil hashCache and a zero inputValue.
+func SignatureScript(tx *wire.MsgTx, idx int, subScript []byte,
+	hashType SigHashType, key *btcec.PrivateKey, compress bool) ([]byte, error) {
+
+	return signatureScript(tx, idx, 0, subScript, hashType, key, nil, compress)
+}
+
+// signatureScript builds a complete signature script for input idx of tx,
+// signing with key. When hashCache is non-nil, the signature hash is
+// computed from its precomputed midstate digests (and inputValue is
+// included in that digest) in O(1) regardless of how many inputs tx has;
+// otherwise the legacy, cache-free sighash is computed from scratch.
+func signatureScript(tx *wire.MsgTx, idx int, inputValue int64, subScript []byte,
+	hashType SigHashType, key *btcec.PrivateKey, hashCache *TxSigHashes,
+	compress bool) ([]byte, error) {
+
+	var hash []byte
+	if hashCache != nil {
+		hash = CalcCachedSignatureHash(tx, idx, inputValue, subScript, hashType, hashCache)
+	} else {
+		legacyHash, err := CalcSignatureHash(subScript, hashType, tx, idx)
+		if err != nil {
+			return nil, err
+		}
+		hash = legacyHash
+	}
+
+	signature, err := key.Sign(hash)
+	if err != nil {
+		return nil, fmt.Errorf("cannot sign tx input: %s", err)
+	}
+
+	pkData := key.PubKey().SerializeCompressed()
+	if !compress {
+		pkData = key.PubKey().SerializeUncompressed()
+	}
+
+	return NewScriptBuilder().
+		AddData(append(signature.Serialize(), byte(hashType))).
+		AddData(pkData).
+		Script()
+}
+
+// CalcCachedSignatureHash computes a BIP143-style signature hash for input
+// idx of tx, reusing the hashPrevOuts/hashSequence/hashOutputs midstate
+// digests in hashCache rather than recomputing them per input. It is the
+// building block NewEngine should call, once its signature grows hashCache
+// and inputValue parameters, for any CHECKSIG-family opcode it evaluates.
+func CalcCachedSignatureHash(tx *wire.MsgTx, idx int, inputValue int64,
+	subScript []byte, hashType SigHashType, hashCache *TxSigHashes) []byte {
+
+	txIn := tx.TxIn[idx]
+
+	var sigHash bytes.Buffer
+
+	binary.Write(&sigHash, binary.LittleEndian, tx.Version)
+	sigHash.Write(hashCache.HashPrevOuts[:])
+	sigHash.Write(hashCache.HashSequence[:])
+
+	sigHash.Write(txIn.PreviousOutPoint.Hash[:])
+	binary.Write(&sigHash, binary.LittleEndian, txIn.PreviousOutPoint.Index)
+
+	sigHash.WriteByte(byte(len(subScript)))
+	sigHash.Write(subScript)
+
+	binary.Write(&sigHash, binary.LittleEndian, inputValue)
+	binary.Write(&sigHash, binary.LittleEndian, txIn.Sequence)
+
+	sigHash.Write(hashCache.HashOutputs[:])
+	binary.Write(&sigHash, binary.LittleEndian, tx.LockTime)
+	binary.Write(&sigHash, binary.LittleEndian, uint32(hashType))
+
+	hash := daghash.DoubleHashH(sigHash.Bytes())
+	return hash[:]
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"github.com/daglabs/btcd/btcec"
+	"github.com/daglabs/btcd/wire"
+)
+
+// Signer abstracts over the production of a signature script for a
+// transaction input. Callers that want to plug in alternative key
+// management, such as a hardware wallet or a remote signer, can implement
+// this interface instead of handing a raw btcec.PrivateKey to
+// SignatureScript.
+type Signer interface {
+	// PubKey returns the public key that corresponds to the signer's
+	// private key material.
+	PubKey() *btcec.PublicKey
+
+	// SignHash signs hash with the signer's private key material and
+	// returns the resulting signature.
+	SignHash(hash []byte) (*btcec.Signature, error)
+
+	// SignatureScript builds a complete signature script for input idx
+	// of tx. hashCache, if non-nil, is used to avoid recomputing the
+	// BIP143-style midstate digests that are shared across every input
+	// of tx.
+	SignatureScript(tx *wire.MsgTx, idx int, inputValue int64,
+		subScript []byte, hashType SigHashType, hashCache *TxSigHashes,
+		compress bool) ([]byte, error)
+}
+
+// privateKeySigner is the default Signer implementation, backed directly by
+// a btcec.PrivateKey. It is what SignatureScript used prior to the
+// introduction of the Signer interface.
+type privateKeySigner struct {
+	key *btcec.PrivateKey
+}
+
+// NewPrivateKeySigner wraps key in a Signer, preserving the existing
+// behavior of signing with a raw private key.
+func NewPrivateKeySigner(key *btcec.PrivateKey) Signer {
+	return &privateKeySigner{key: key}
+}
+
+// PubKey returns the public key of the wrapped private key.
+func (s *privateKeySigner) PubKey() *btcec.PublicKey {
+	return s.key.PubKey()
+}
+
+// SignHash signs hash with the wrapped private key.
+func (s *privateKeySigner) SignHash(hash []byte) (*btcec.Signature, error) {
+	return s.key.Sign(hash)
+}
+
+// SignatureScript builds a signature script for the given input, delegating
+// to signatureScript with the wrapped private key, hashCache and inputValue.
+func (s *privateKeySigner) SignatureScript(tx *wire.MsgTx, idx int, inputValue int64,
+	subScript []byte, hashType SigHashType, hashCache *TxSigHashes,
+	compress bool) ([]byte, error) {
+
+	return signatureScript(tx, idx, inputValue, subScript, hashType,
+		s.key, hashCache, compress)
+}